@@ -0,0 +1,234 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime/debug"
+	"time"
+)
+
+// CheckResult is the outcome of a single execution of a Check, as surfaced
+// through Checker.Check and StatusChangeListener.
+type CheckResult struct {
+	Status Status
+	// Timestamp is when this result was produced.
+	Timestamp time.Time
+	// StartedAt is when the underlying Check function was invoked.
+	StartedAt time.Time
+	// Duration is how long the underlying Check function took to return.
+	Duration time.Duration
+	Error    *string
+}
+
+// Check describes a single health check to be executed by a Checker, either
+// on demand or periodically in the background.
+type Check struct {
+	// Name uniquely identifies this check among all checks registered with a Checker.
+	Name string
+	// Check is executed to determine whether the checked component is healthy.
+	// A non-nil error marks the check as failed.
+	Check func(ctx context.Context) error
+	// Timeout bounds how long Check is allowed to run. The zero value means no timeout.
+	Timeout time.Duration
+	// DependsOn lists the names of other checks that must be healthy before
+	// this one runs. If any of them last reported StatusDown, this check is
+	// skipped instead of executed.
+	DependsOn []string
+
+	// refreshInterval, when greater than zero, makes the checker execute this
+	// check periodically in the background instead of inline on every call to
+	// Checker.Check.
+	refreshInterval time.Duration
+	// maxTimeInError is how long a check may keep failing before it is
+	// considered StatusDown rather than StatusUp. Zero means no grace period.
+	maxTimeInError time.Duration
+	// maxFails is how many consecutive failures a check may accumulate before
+	// it is considered StatusDown rather than StatusUp. Zero means no grace period.
+	maxFails uint
+	// backoff, when set, replaces refreshInterval as the delay before the next
+	// periodic run while the check is failing.
+	backoff *backoffConfig
+	// maxResponseTime, when exceeded by an otherwise successful check,
+	// downgrades its result to StatusUnknown instead of StatusUp, so a
+	// degraded-but-passing dependency doesn't look identical to a healthy one.
+	maxResponseTime time.Duration
+}
+
+// backoffConfig describes the exponential backoff applied to a periodic
+// check's schedule while it keeps failing.
+type backoffConfig struct {
+	initial time.Duration
+	max     time.Duration
+	factor  float64
+}
+
+// nextRefreshInterval returns the delay before the next periodic run of
+// check, given how many times it has failed in a row. Without a configured
+// backoff, or while the check is passing, this is simply check.refreshInterval.
+// Otherwise it grows as initial*factor^(consecutiveFails-1), capped at max,
+// plus a small amount of jitter to avoid synchronized retries.
+func nextRefreshInterval(check *Check, consecutiveFails uint) time.Duration {
+	if check.backoff == nil || consecutiveFails == 0 {
+		return check.refreshInterval
+	}
+
+	b := check.backoff
+	delay := float64(b.initial) * math.Pow(b.factor, float64(consecutiveFails-1))
+	if b.max > 0 && delay > float64(b.max) {
+		delay = float64(b.max)
+	}
+	return addJitter(time.Duration(delay))
+}
+
+// addJitter adds up to ~20% of random jitter on top of d, so that many checks
+// backing off at the same time don't all retry in lockstep.
+func addJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// checkState is the internal, per-check bookkeeping a Checker keeps between
+// executions of a Check.
+type checkState struct {
+	startedAt        time.Time
+	lastCheckedAt    time.Time
+	lastResult       error
+	lastSuccessAt    time.Time
+	consecutiveFails uint
+	lastStartedAt    time.Time
+	lastDuration     time.Duration
+}
+
+// evaluateAvailabilityStatus derives a Status from a check's current state.
+// A failing check is only considered down once both the maxFails and the
+// maxTimeInError grace periods (when configured) have been exceeded; a zero
+// grace period is treated as "no grace at all".
+func evaluateAvailabilityStatus(state *checkState, maxTimeInError time.Duration, maxFails uint) Status {
+	if state.lastCheckedAt.IsZero() {
+		return StatusUnknown
+	}
+	if state.lastResult == nil {
+		return StatusUp
+	}
+
+	sinceLastSuccess := state.lastCheckedAt.Sub(state.startedAt)
+	if !state.lastSuccessAt.IsZero() {
+		sinceLastSuccess = state.lastCheckedAt.Sub(state.lastSuccessAt)
+	}
+
+	failsExceeded := maxFails == 0 || state.consecutiveFails >= maxFails
+	timeExceeded := maxTimeInError == 0 || sinceLastSuccess >= maxTimeInError
+	if failsExceeded && timeExceeded {
+		return StatusDown
+	}
+	return StatusUp
+}
+
+// isSlowSuccess reports whether state reflects a check that succeeded but
+// took longer than maxResponseTime to do so. Such a check is reported as
+// StatusUnknown rather than StatusUp, so degraded-but-passing dependencies
+// don't look identical to healthy ones. maxResponseTime of zero disables this.
+func isSlowSuccess(state *checkState, maxResponseTime time.Duration) bool {
+	return maxResponseTime > 0 && state.lastResult == nil && state.lastDuration > maxResponseTime
+}
+
+// effectiveStatus derives the Status chk would currently report given state,
+// applying the same maxFails/maxTimeInError grace period and maxResponseTime
+// downgrade as doCheck. It is shared by Checker.Check's cached results and by
+// the Prometheus exporter, so the two never disagree about whether a check
+// is healthy.
+func effectiveStatus(state *checkState, chk *Check) Status {
+	status := evaluateAvailabilityStatus(state, chk.maxTimeInError, chk.maxFails)
+	if isSlowSuccess(state, chk.maxResponseTime) {
+		return StatusUnknown
+	}
+	return status
+}
+
+// toErrorDesc renders err as a string truncated to at most maxLen characters,
+// or nil if err is nil.
+func toErrorDesc(err error, maxLen int) *string {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if len(msg) > maxLen {
+		msg = msg[:maxLen]
+	}
+	return &msg
+}
+
+// executeCheckFunc runs check.Check, aborting with a timeout error if ctx is
+// done before the check function returns. A panicking Check is recovered and
+// turned into an error carrying the panic value and stack trace, so that a
+// single misbehaving check can never crash the process.
+func executeCheckFunc(ctx context.Context, check *Check) error {
+	resultChan := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				resultChan <- fmt.Errorf("check panicked: %v\n%s", r, debug.Stack())
+			}
+		}()
+		resultChan <- check.Check(ctx)
+	}()
+
+	select {
+	case err := <-resultChan:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out")
+	}
+}
+
+// checkOutcome bundles the CheckResult produced by a single execution of a
+// check together with the checkState that should replace the previous one.
+type checkOutcome struct {
+	result   CheckResult
+	newState checkState
+}
+
+// doCheck executes check once, advancing state accordingly, and returns both
+// the resulting CheckResult and the updated checkState.
+func doCheck(ctx context.Context, check Check, state checkState) checkOutcome {
+	now := time.Now().UTC()
+	if state.startedAt.IsZero() {
+		state.startedAt = now
+	}
+
+	startedAt := time.Now()
+	err := executeCheckFunc(ctx, &check)
+	duration := time.Since(startedAt)
+
+	newState := state
+	newState.lastCheckedAt = now
+	newState.lastResult = err
+	newState.lastStartedAt = startedAt
+	newState.lastDuration = duration
+	if err == nil {
+		newState.consecutiveFails = 0
+		newState.lastSuccessAt = now
+	} else {
+		newState.consecutiveFails = state.consecutiveFails + 1
+	}
+
+	status := evaluateAvailabilityStatus(&newState, check.maxTimeInError, check.maxFails)
+	if isSlowSuccess(&newState, check.maxResponseTime) {
+		status = StatusUnknown
+	}
+
+	return checkOutcome{
+		result: CheckResult{
+			Status:    status,
+			Timestamp: now,
+			StartedAt: startedAt,
+			Duration:  duration,
+			Error:     toErrorDesc(err, maxErrMsgLenUnbounded),
+		},
+		newState: newState,
+	}
+}
@@ -41,7 +41,7 @@ func TestStatusDownBeforeStatusUnknown(t *testing.T) {
 func TestNewAggregatedCheckStatusWithDetails(t *testing.T) {
 	// Arrange
 	errMsg := "this is an error message"
-	testData := map[string]CheckResult{"check1": {StatusDown, time.Now(), &errMsg}}
+	testData := map[string]CheckResult{"check1": {Status: StatusDown, Timestamp: time.Now(), Error: &errMsg}}
 
 	// Act
 	result := newAggregatedCheckStatus(StatusDown, testData, true)
@@ -217,6 +217,97 @@ func TestExecuteCheckFuncWithTimeout(t *testing.T) {
 	assert.Equal(t, "check timed out", result.Error())
 }
 
+func TestExecuteCheckFuncRecoversFromPanic(t *testing.T) {
+	// Arrange
+	check := Check{
+		Check: func(ctx context.Context) error {
+			panic("boom")
+		},
+	}
+
+	// Act
+	result := executeCheckFunc(context.Background(), &check)
+
+	// Assert
+	require.NotNil(t, result)
+	assert.Contains(t, result.Error(), "check panicked: boom")
+}
+
+func TestPeriodicCheckSurvivesPanickingCheck(t *testing.T) {
+	// Arrange
+	ckr := newChecker(healthCheckConfig{
+		checks: []*Check{
+			{
+				Name: "check",
+				Check: func(ctx context.Context) error {
+					panic("boom")
+				},
+				refreshInterval: 5 * time.Millisecond,
+			},
+		},
+	})
+	defer ckr.StopPeriodicChecks()
+
+	// Act
+	time.Sleep(50 * time.Millisecond)
+
+	// Assert
+	assert.Equal(t, 1, len(ckr.endChans), "periodic goroutine must keep running despite a panicking check")
+}
+
+func TestRecordPeriodicCheckPanicClearsEndChanAndRecordsFailure(t *testing.T) {
+	// Arrange
+	chk := &Check{Name: "check"}
+	ckr := newChecker(healthCheckConfig{
+		checks:                   []*Check{chk},
+		manualPeriodicCheckStart: true,
+	})
+	ckr.endChans["check"] = make(chan struct{})
+
+	// Act
+	ckr.recordPeriodicCheckPanic(chk, "boom")
+
+	// Assert
+	assert.Len(t, ckr.endChans, 0, "a dead periodic goroutine must not be tracked as still running")
+	state := ckr.states["check"]
+	assert.Equal(t, uint(1), state.consecutiveFails)
+	require.NotNil(t, state.lastResult)
+	assert.Contains(t, state.lastResult.Error(), "periodic check goroutine panicked: boom")
+}
+
+func TestNextRefreshIntervalWithoutBackoffUsesFixedInterval(t *testing.T) {
+	check := Check{refreshInterval: 10 * time.Second}
+
+	assert.Equal(t, 10*time.Second, nextRefreshInterval(&check, 0))
+	assert.Equal(t, 10*time.Second, nextRefreshInterval(&check, 5))
+}
+
+func TestNextRefreshIntervalBackoffGrowsWithConsecutiveFails(t *testing.T) {
+	check := Check{
+		refreshInterval: time.Second,
+		backoff:         &backoffConfig{initial: time.Second, max: time.Minute, factor: 2},
+	}
+
+	first := nextRefreshInterval(&check, 1)
+	second := nextRefreshInterval(&check, 2)
+	third := nextRefreshInterval(&check, 3)
+
+	assert.GreaterOrEqual(t, int64(first), int64(time.Second))
+	assert.Greater(t, int64(second), int64(first))
+	assert.Greater(t, int64(third), int64(second))
+}
+
+func TestNextRefreshIntervalBackoffCapsAtMax(t *testing.T) {
+	check := Check{
+		refreshInterval: time.Second,
+		backoff:         &backoffConfig{initial: time.Second, max: 3 * time.Second, factor: 2},
+	}
+
+	interval := nextRefreshInterval(&check, 100)
+
+	assert.LessOrEqual(t, int64(interval), int64(3*time.Second)+int64(3*time.Second)/5+1)
+}
+
 func TestInternalCheckWithCheckError(t *testing.T) {
 	// Arrange
 	check := Check{
@@ -264,6 +355,43 @@ func TestInternalCheckWithCheckSuccess(t *testing.T) {
 	assert.Equal(t, true, state.startedAt.Equal(result.newState.startedAt))
 	assert.Equal(t, "UTC", result.newState.lastCheckedAt.Format("MST"))
 	assert.Equal(t, uint(0), result.newState.consecutiveFails)
+	assert.Equal(t, StatusUp, result.result.Status)
+	assert.False(t, result.result.StartedAt.IsZero())
+	assert.GreaterOrEqual(t, result.result.Duration, time.Duration(0))
+}
+
+func TestInternalCheckWithSlowCheckSuccessReportsUnknown(t *testing.T) {
+	// Arrange
+	check := Check{
+		Check: func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		},
+		maxResponseTime: 1 * time.Millisecond,
+	}
+
+	// Act
+	result := doCheck(context.Background(), check, checkState{})
+
+	// Assert
+	assert.Equal(t, StatusUnknown, result.result.Status)
+	assert.Greater(t, result.result.Duration, check.maxResponseTime)
+}
+
+func TestInternalCheckWithFastCheckSuccessReportsUp(t *testing.T) {
+	// Arrange
+	check := Check{
+		Check: func(ctx context.Context) error {
+			return nil
+		},
+		maxResponseTime: 1 * time.Hour,
+	}
+
+	// Act
+	result := doCheck(context.Background(), check, checkState{})
+
+	// Assert
+	assert.Equal(t, StatusUp, result.result.Status)
 }
 
 func doTestCheckerCheckFunc(t *testing.T, refreshInterval time.Duration, err error, expectedStatus Status) {
@@ -310,6 +438,104 @@ func TestCheckSuccessNotAllChecksExecutedYet(t *testing.T) {
 	doTestCheckerCheckFunc(t, 5*time.Hour, nil, StatusUnknown)
 }
 
+func TestSubscribeReceivesEventOnStatusChange(t *testing.T) {
+	// Arrange
+	expectedErrMsg := "ohi"
+	checks := []*Check{
+		{
+			Name: "check",
+			Check: func(ctx context.Context) error {
+				return fmt.Errorf(expectedErrMsg)
+			},
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+	events, unsubscribe := ckr.Subscribe()
+	defer unsubscribe()
+
+	// Act
+	ckr.Check(context.Background())
+
+	// Assert
+	select {
+	case event := <-events:
+		assert.Equal(t, StatusDown, event.Status)
+		assert.Equal(t, StatusDown, event.Checks["check"].Status)
+	case <-time.After(time.Second):
+		t.Fatal("expected a StatusEvent to be delivered")
+	}
+}
+
+func TestSubscribeReceivesEventOnPerCheckStatusChangeWithoutAggregateChange(t *testing.T) {
+	// Arrange: database and cache swap which one is failing, so the
+	// aggregate stays StatusDown across both Check() calls even though
+	// database itself goes from down to up.
+	databaseCalls := 0
+	cacheCalls := 0
+	checks := []*Check{
+		{
+			Name: "database",
+			Check: func(ctx context.Context) error {
+				databaseCalls++
+				if databaseCalls == 1 {
+					return fmt.Errorf("database down")
+				}
+				return nil
+			},
+		},
+		{
+			Name: "cache",
+			Check: func(ctx context.Context) error {
+				cacheCalls++
+				if cacheCalls == 1 {
+					return nil
+				}
+				return fmt.Errorf("cache down")
+			},
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+	events, unsubscribe := ckr.Subscribe()
+	defer unsubscribe()
+
+	// Act
+	first := ckr.Check(context.Background())
+	require.Equal(t, StatusDown, first.Status)
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Fatal("expected the initial StatusEvent to be delivered")
+	}
+
+	second := ckr.Check(context.Background())
+	require.Equal(t, StatusDown, second.Status, "aggregate must stay down: cache replaces database as the failing check")
+
+	// Assert
+	select {
+	case event := <-events:
+		assert.Equal(t, StatusUp, event.Checks["database"].Status)
+		assert.Equal(t, StatusDown, event.Checks["cache"].Status)
+	case <-time.After(time.Second):
+		t.Fatal("expected a StatusEvent for the per-check transition even though the aggregate didn't change")
+	}
+}
+
+func TestUnsubscribeStopsFurtherDeliveryAndClosesChannel(t *testing.T) {
+	// Arrange
+	ckr := newChecker(healthCheckConfig{checks: []*Check{{
+		Name:  "check",
+		Check: func(ctx context.Context) error { return nil },
+	}}})
+	events, unsubscribe := ckr.Subscribe()
+
+	// Act
+	unsubscribe()
+
+	// Assert
+	_, open := <-events
+	assert.False(t, open, "channel must be closed after unsubscribe")
+}
+
 func TestCheckExecuteListeners(t *testing.T) {
 	// Arrange
 	var (
@@ -348,3 +574,93 @@ func TestCheckExecuteListeners(t *testing.T) {
 	assert.Equal(t, StatusDown, (*actualResults)[expectedCheckName].Status)
 	assert.True(t, (*actualResults)[expectedCheckName].Timestamp.After(testStartedAt))
 }
+
+func TestCheckSkipsDependentWhenDependencyIsDown(t *testing.T) {
+	// Arrange
+	dependentWasCalled := false
+	checks := []*Check{
+		{
+			Name: "database",
+			Check: func(ctx context.Context) error {
+				return fmt.Errorf("connection refused")
+			},
+		},
+		{
+			Name:      "orders-api",
+			DependsOn: []string{"database"},
+			Check: func(ctx context.Context) error {
+				dependentWasCalled = true
+				return nil
+			},
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+
+	// Act
+	res := ckr.Check(context.Background())
+
+	// Assert
+	assert.False(t, dependentWasCalled, "a check must not run while its dependency is down")
+	assert.Equal(t, StatusDown, res.Status)
+	dependent := (*res.Details)["orders-api"]
+	assert.Equal(t, StatusDown, dependent.Status)
+	require.NotNil(t, dependent.Error)
+	assert.Equal(t, "skipped: dependency database is down", *dependent.Error)
+}
+
+func TestCheckSkippedResultRespectsMaxErrorMessageLength(t *testing.T) {
+	// Arrange
+	checks := []*Check{
+		{
+			Name: "database",
+			Check: func(ctx context.Context) error {
+				return fmt.Errorf("connection refused")
+			},
+		},
+		{
+			Name:      "orders-api",
+			DependsOn: []string{"database"},
+			Check:     func(ctx context.Context) error { return nil },
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks, maxErrMsgLen: 7})
+
+	// Act
+	res := ckr.Check(context.Background())
+
+	// Assert
+	dependent := (*res.Details)["orders-api"]
+	require.NotNil(t, dependent.Error)
+	assert.Equal(t, "skipped", *dependent.Error, "skipped results must be truncated like any other check error")
+}
+
+func TestCheckRunsDependentWhenDependencyIsUp(t *testing.T) {
+	// Arrange
+	checks := []*Check{
+		{Name: "database", Check: func(ctx context.Context) error { return nil }},
+		{
+			Name:      "orders-api",
+			DependsOn: []string{"database"},
+			Check:     func(ctx context.Context) error { return nil },
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+
+	// Act
+	res := ckr.Check(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusUp, res.Status)
+	assert.Equal(t, StatusUp, (*res.Details)["orders-api"].Status)
+}
+
+func TestNewCheckerPanicsOnDependencyCycle(t *testing.T) {
+	checks := []*Check{
+		{Name: "a", DependsOn: []string{"b"}, Check: func(ctx context.Context) error { return nil }},
+		{Name: "b", DependsOn: []string{"a"}, Check: func(ctx context.Context) error { return nil }},
+	}
+
+	assert.Panics(t, func() {
+		newChecker(healthCheckConfig{checks: checks})
+	})
+}
@@ -0,0 +1,451 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxErrMsgLenUnbounded is used wherever an error message should not be
+// truncated, i.e. when no maxErrMsgLen was configured.
+const maxErrMsgLenUnbounded = int(^uint(0) >> 1)
+
+// defaultWorkerPoolSize bounds how many checks belonging to the same
+// dependency layer run concurrently when no WithWorkerPoolSize was configured.
+const defaultWorkerPoolSize = 8
+
+// StatusChangeListener is invoked whenever the aggregated status returned by
+// Checker.Check changes.
+type StatusChangeListener func(status Status, checks map[string]CheckResult)
+
+// CheckerResult is the aggregated outcome of running all checks registered
+// with a Checker.
+type CheckerResult struct {
+	Status  Status
+	Details *map[string]CheckResult
+}
+
+// StatusEvent is delivered to subscribers returned by Checker.Subscribe
+// whenever the aggregated status changes, or any individual check's status
+// changes even if the aggregate does not.
+type StatusEvent struct {
+	Status Status
+	Checks map[string]CheckResult
+}
+
+// newAggregatedCheckStatus builds a CheckerResult, attaching details only
+// when withDetails is true.
+func newAggregatedCheckStatus(status Status, details map[string]CheckResult, withDetails bool) *CheckerResult {
+	result := &CheckerResult{Status: status}
+	if withDetails {
+		result.Details = &details
+	}
+	return result
+}
+
+// Checker runs a set of Check functions, either synchronously on demand or
+// periodically in the background, and reports their aggregated status.
+type Checker interface {
+	// Check executes all checks that are not configured to run periodically
+	// and returns the aggregated result, including the cached result of any
+	// periodically running check.
+	Check(ctx context.Context) CheckerResult
+	// StartPeriodicChecks starts a background goroutine for every check that
+	// has a refresh interval configured. It is a no-op for checks already running.
+	StartPeriodicChecks()
+	// StopPeriodicChecks stops all goroutines started by StartPeriodicChecks.
+	StopPeriodicChecks()
+	// RegisterMetrics registers a Prometheus collector exposing this
+	// checker's state with reg.
+	RegisterMetrics(reg prometheus.Registerer) error
+	// Subscribe returns a channel that receives a StatusEvent whenever the
+	// aggregated status changes or any individual check's status changes, and
+	// an unsubscribe func that must be called once the caller is done reading
+	// from it. The channel is closed by unsubscribe and is never sent to
+	// afterwards. Events are sent without blocking: the channel buffers a
+	// single event, and an event that arrives while it is still full is
+	// dropped.
+	Subscribe() (<-chan StatusEvent, func())
+}
+
+// checkObserver is notified after every live execution of a check. It exists
+// so that auxiliary features (such as metrics export) can observe checks
+// without the checker depending on them directly.
+type checkObserver func(name string, result CheckResult, duration time.Duration)
+
+// healthCheckConfig holds the configuration a checker is built from.
+type healthCheckConfig struct {
+	manualPeriodicCheckStart bool
+	checks                   []*Check
+	statusChangeListeners    []StatusChangeListener
+	maxErrMsgLen             uint
+	workerPoolSize           int
+}
+
+// checkerImpl is the default Checker implementation.
+type checkerImpl struct {
+	cfg healthCheckConfig
+	// layers holds cfg.checks grouped by Check.DependsOn depth: every check in
+	// layers[n] only depends on checks in layers[0:n].
+	layers [][]*Check
+
+	mtx         sync.Mutex
+	states      map[string]checkState
+	endChans    map[string]chan struct{}
+	lastStatus  Status
+	lastResults map[string]CheckResult
+	observers   []checkObserver
+	subscribers map[int]chan StatusEvent
+	nextSubID   int
+}
+
+// newChecker builds a checkerImpl from cfg and starts its periodic checks
+// unless cfg.manualPeriodicCheckStart is set. It panics if cfg.checks'
+// DependsOn fields form a cycle or reference an unknown check, since that is
+// a programming error that can only be caught at startup.
+func newChecker(cfg healthCheckConfig) *checkerImpl {
+	layers, err := buildLayers(cfg.checks)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &checkerImpl{
+		cfg:      cfg,
+		layers:   layers,
+		states:   make(map[string]checkState, len(cfg.checks)),
+		endChans: make(map[string]chan struct{}),
+	}
+	for _, chk := range cfg.checks {
+		c.states[chk.Name] = checkState{}
+	}
+	if !cfg.manualPeriodicCheckStart {
+		c.StartPeriodicChecks()
+	}
+	return c
+}
+
+// NewChecker builds a Checker from the given options.
+func NewChecker(opts ...Option) Checker {
+	cfg := healthCheckConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return newChecker(cfg)
+}
+
+func (c *checkerImpl) StartPeriodicChecks() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, chk := range c.cfg.checks {
+		if chk.refreshInterval <= 0 {
+			continue
+		}
+		if _, running := c.endChans[chk.Name]; running {
+			continue
+		}
+		end := make(chan struct{})
+		c.endChans[chk.Name] = end
+		go c.runPeriodicCheck(chk, end)
+	}
+}
+
+func (c *checkerImpl) StopPeriodicChecks() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for name, end := range c.endChans {
+		close(end)
+		delete(c.endChans, name)
+	}
+}
+
+// runPeriodicCheck runs chk on a timer until end is closed. The delay before
+// each run is recomputed from chk's backoff configuration (if any) and the
+// check's current consecutiveFails, so a failing check backs off instead of
+// retrying at a fixed interval. executeCheckFunc already recovers panics from
+// the check function itself; a panic escaping this loop's own bookkeeping
+// (e.g. a bug in nextRefreshInterval) is recovered by recordPeriodicCheckPanic
+// so that it can never take down the process, is recorded as a failing
+// result instead of vanishing silently, and no longer leaves this check
+// looking like its periodic goroutine is still running.
+func (c *checkerImpl) runPeriodicCheck(chk *Check, end chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.recordPeriodicCheckPanic(chk, r)
+		}
+	}()
+
+	timer := time.NewTimer(chk.refreshInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-end:
+			return
+		case <-timer.C:
+			c.runCheck(context.Background(), chk)
+
+			c.mtx.Lock()
+			fails := c.states[chk.Name].consecutiveFails
+			c.mtx.Unlock()
+			timer.Reset(nextRefreshInterval(chk, fails))
+		}
+	}
+}
+
+// recordPeriodicCheckPanic is invoked when a panic escapes runPeriodicCheck's
+// own loop, as opposed to the check function (which executeCheckFunc already
+// recovers from). It records chk as freshly failed, so the last known state
+// doesn't keep reporting stale success forever, and removes chk's endChans
+// entry so a subsequent StartPeriodicChecks call treats it as not running
+// and restarts it, instead of believing the dead goroutine is still alive.
+func (c *checkerImpl) recordPeriodicCheckPanic(chk *Check, r interface{}) {
+	err := fmt.Errorf("periodic check goroutine panicked: %v\n%s", r, debug.Stack())
+	now := time.Now()
+
+	c.mtx.Lock()
+	state := c.states[chk.Name]
+	state.lastCheckedAt = now
+	state.lastResult = err
+	state.consecutiveFails++
+	c.states[chk.Name] = state
+	delete(c.endChans, chk.Name)
+	observers := c.observers
+	c.mtx.Unlock()
+
+	result := CheckResult{
+		Status:    StatusDown,
+		Timestamp: now,
+		Error:     toErrorDesc(err, c.effectiveMaxErrMsgLen()),
+	}
+	for _, observe := range observers {
+		observe(chk.Name, result, 0)
+	}
+}
+
+// Check implements Checker. Checks are executed one dependency layer at a
+// time; within a layer, independent checks run concurrently, bounded by a
+// worker pool (see WithWorkerPoolSize). When a check's dependency last
+// reported StatusDown, the check is skipped rather than executed.
+func (c *checkerImpl) Check(ctx context.Context) CheckerResult {
+	results := make(map[string]CheckResult, len(c.cfg.checks))
+	var resultsMtx sync.Mutex
+
+	pool := make(chan struct{}, c.workerPoolSize())
+	for _, layer := range c.layers {
+		var wg sync.WaitGroup
+		for _, chk := range layer {
+			chk := chk
+
+			resultsMtx.Lock()
+			blockingDep, blocked := blockingDependency(chk, results)
+			resultsMtx.Unlock()
+			if blocked {
+				resultsMtx.Lock()
+				results[chk.Name] = c.skippedResult(blockingDep)
+				resultsMtx.Unlock()
+				continue
+			}
+
+			wg.Add(1)
+			pool <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-pool }()
+
+				var result CheckResult
+				if chk.refreshInterval > 0 {
+					result = c.cachedResult(chk)
+				} else {
+					result = c.runCheck(ctx, chk)
+				}
+
+				resultsMtx.Lock()
+				results[chk.Name] = result
+				resultsMtx.Unlock()
+			}()
+		}
+		wg.Wait()
+	}
+
+	status := aggregateStatus(results)
+	c.notifyListeners(status, results)
+	return *newAggregatedCheckStatus(status, results, true)
+}
+
+// blockingDependency reports the first dependency of chk whose most recent
+// result in results is StatusDown, if any.
+func blockingDependency(chk *Check, results map[string]CheckResult) (string, bool) {
+	for _, dep := range chk.DependsOn {
+		if results[dep].Status == StatusDown {
+			return dep, true
+		}
+	}
+	return "", false
+}
+
+// skippedResult is recorded for a check that was not executed because
+// blockingDep, one of its dependencies, is down.
+func (c *checkerImpl) skippedResult(blockingDep string) CheckResult {
+	err := fmt.Errorf("skipped: dependency %s is down", blockingDep)
+	return CheckResult{
+		Status:    StatusDown,
+		Timestamp: time.Now(),
+		Error:     toErrorDesc(err, c.effectiveMaxErrMsgLen()),
+	}
+}
+
+// workerPoolSize returns how many checks belonging to the same dependency
+// layer may run concurrently.
+func (c *checkerImpl) workerPoolSize() int {
+	if c.cfg.workerPoolSize > 0 {
+		return c.cfg.workerPoolSize
+	}
+	return defaultWorkerPoolSize
+}
+
+// runCheck executes chk inline, persists the resulting state and notifies
+// any registered checkObservers.
+func (c *checkerImpl) runCheck(ctx context.Context, chk *Check) CheckResult {
+	c.mtx.Lock()
+	state := c.states[chk.Name]
+	c.mtx.Unlock()
+
+	outcome := doCheck(ctx, *chk, state)
+
+	c.mtx.Lock()
+	c.states[chk.Name] = outcome.newState
+	observers := c.observers
+	c.mtx.Unlock()
+
+	outcome.result.Error = toErrorDesc(outcome.newState.lastResult, c.effectiveMaxErrMsgLen())
+	for _, observe := range observers {
+		observe(chk.Name, outcome.result, outcome.result.Duration)
+	}
+	return outcome.result
+}
+
+// cachedResult returns the result of chk as of its last periodic execution,
+// without running it.
+func (c *checkerImpl) cachedResult(chk *Check) CheckResult {
+	c.mtx.Lock()
+	state := c.states[chk.Name]
+	c.mtx.Unlock()
+
+	return CheckResult{
+		Status:    effectiveStatus(&state, chk),
+		Timestamp: state.lastCheckedAt,
+		StartedAt: state.lastStartedAt,
+		Duration:  state.lastDuration,
+		Error:     toErrorDesc(state.lastResult, c.effectiveMaxErrMsgLen()),
+	}
+}
+
+func (c *checkerImpl) notifyListeners(status Status, results map[string]CheckResult) {
+	c.mtx.Lock()
+	aggregateChanged := status != c.lastStatus
+	anyCheckChanged := c.anyCheckStatusChangedLocked(results)
+	c.lastStatus = status
+	c.lastResults = results
+	var subs []chan StatusEvent
+	if aggregateChanged || anyCheckChanged {
+		for _, ch := range c.subscribers {
+			subs = append(subs, ch)
+		}
+	}
+	c.mtx.Unlock()
+
+	if aggregateChanged {
+		for _, listener := range c.cfg.statusChangeListeners {
+			listener(status, results)
+		}
+	}
+
+	if len(subs) == 0 {
+		return
+	}
+
+	event := StatusEvent{Status: status, Checks: results}
+	for _, ch := range subs {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscriber: drop the event rather than block Check().
+		}
+	}
+}
+
+// anyCheckStatusChangedLocked reports whether any check in results has a
+// different Status than it did after the previous Check call. c.mtx must be
+// held. A check that is present in results but was not in the previous call
+// (or vice versa) counts as changed.
+func (c *checkerImpl) anyCheckStatusChangedLocked(results map[string]CheckResult) bool {
+	if len(results) != len(c.lastResults) {
+		return true
+	}
+	for name, result := range results {
+		prev, ok := c.lastResults[name]
+		if !ok || prev.Status != result.Status {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe implements Checker.
+func (c *checkerImpl) Subscribe() (<-chan StatusEvent, func()) {
+	ch := make(chan StatusEvent, 1)
+
+	c.mtx.Lock()
+	if c.subscribers == nil {
+		c.subscribers = map[int]chan StatusEvent{}
+	}
+	id := c.nextSubID
+	c.nextSubID++
+	c.subscribers[id] = ch
+	c.mtx.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			c.mtx.Lock()
+			delete(c.subscribers, id)
+			c.mtx.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+func (c *checkerImpl) effectiveMaxErrMsgLen() int {
+	if c.cfg.maxErrMsgLen == 0 {
+		return maxErrMsgLenUnbounded
+	}
+	return int(c.cfg.maxErrMsgLen)
+}
+
+// snapshotStates returns a copy of the checker's internal per-check state,
+// safe to read without holding c.mtx.
+func (c *checkerImpl) snapshotStates() map[string]checkState {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	out := make(map[string]checkState, len(c.states))
+	for name, state := range c.states {
+		out[name] = state
+	}
+	return out
+}
+
+// addObserver registers o to be called after every live execution of a check.
+func (c *checkerImpl) addObserver(o checkObserver) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.observers = append(c.observers, o)
+}
+
+// RegisterMetrics implements Checker.
+func (c *checkerImpl) RegisterMetrics(reg prometheus.Registerer) error {
+	return reg.Register(NewPrometheusCollector(c))
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+)
+
+// buildLayers groups checks into layers such that every check in a layer
+// only depends (transitively, via Check.DependsOn) on checks in earlier
+// layers. Checks within a layer have no dependency relationship between them
+// and can be executed concurrently. It returns an error if a check depends on
+// an unknown check, or if the dependency graph contains a cycle.
+func buildLayers(checks []*Check) ([][]*Check, error) {
+	byName := make(map[string]*Check, len(checks))
+	for _, chk := range checks {
+		byName[chk.Name] = chk
+	}
+
+	inDegree := make(map[string]int, len(checks))
+	dependents := make(map[string][]string, len(checks))
+	for _, chk := range checks {
+		inDegree[chk.Name] = len(chk.DependsOn)
+		for _, dep := range chk.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("health: check %q depends on unknown check %q", chk.Name, dep)
+			}
+			dependents[dep] = append(dependents[dep], chk.Name)
+		}
+	}
+
+	var ready []string
+	for name, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, name)
+		}
+	}
+	sort.Strings(ready)
+
+	var layers [][]*Check
+	resolved := 0
+	for len(ready) > 0 {
+		layer := make([]*Check, 0, len(ready))
+		var next []string
+		for _, name := range ready {
+			layer = append(layer, byName[name])
+			resolved++
+			for _, dependent := range dependents[name] {
+				inDegree[dependent]--
+				if inDegree[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		sort.Strings(next)
+		layers = append(layers, layer)
+		ready = next
+	}
+
+	if resolved != len(checks) {
+		return nil, fmt.Errorf("health: DependsOn graph contains a cycle")
+	}
+	return layers, nil
+}
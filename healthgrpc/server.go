@@ -0,0 +1,119 @@
+// Package healthgrpc adapts a health.Checker to the standard
+// grpc.health.v1.Health service, so it can be probed by any grpc-ecosystem
+// tooling without a hand-rolled adapter.
+package healthgrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/panta/health"
+)
+
+// grpcHealthServer implements grpc_health_v1.HealthServer on top of a
+// health.Checker.
+type grpcHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	checker health.Checker
+}
+
+// NewGRPCHealthServer builds a grpc_health_v1.HealthServer backed by checker.
+// An empty HealthCheckRequest.Service reports the aggregated status; a
+// non-empty one reports the status of the check with that name. If no such
+// check is registered, Check fails with codes.NotFound and Watch reports
+// SERVICE_UNKNOWN.
+func NewGRPCHealthServer(checker health.Checker) grpc_health_v1.HealthServer {
+	return &grpcHealthServer{checker: checker}
+}
+
+// Check implements grpc_health_v1.HealthServer.
+func (s *grpcHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	servingStatus, ok := serviceStatus(s.checker.Check(ctx), req.GetService())
+	if !ok {
+		return nil, grpcstatus.Error(codes.NotFound, "unknown service")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch implements grpc_health_v1.HealthServer. It streams the requested
+// service's status on every connect and whenever that service's status
+// subsequently changes. Status changes are read straight off the StatusEvent
+// delivered by Subscribe rather than by calling Check again, so an open
+// Watch stream never re-triggers the checker's (possibly inline, synchronous)
+// checks; Check is only called once, up front, to resolve the first status
+// before any event has arrived. The checker may deliver events that leave
+// the requested service's status unchanged (e.g. another check's status
+// moved, or only the aggregate recomputed); those are not forwarded to the
+// client.
+func (s *grpcHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	events, unsubscribe := s.checker.Subscribe()
+	defer unsubscribe()
+
+	resolve := func(result health.CheckerResult) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		servingStatus, ok := serviceStatus(result, req.GetService())
+		if !ok {
+			return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		return servingStatus
+	}
+
+	last := resolve(s.checker.Check(stream.Context()))
+	if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: last}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			current := resolve(health.CheckerResult{Status: event.Status, Details: &event.Checks})
+			if current == last {
+				continue
+			}
+			last = current
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: last}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// serviceStatus resolves the serving status of service within result. An
+// empty service name resolves to the aggregated status. The bool return
+// value is false when service does not match any registered check.
+func serviceStatus(result health.CheckerResult, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+	if service == "" {
+		return toServingStatus(result.Status), true
+	}
+	if result.Details == nil {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	check, ok := (*result.Details)[service]
+	if !ok {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	return toServingStatus(check.Status), true
+}
+
+// toServingStatus maps this package's up/down/unknown status to the gRPC
+// health-checking protocol's serving status. StatusUnknown maps to UNKNOWN,
+// not SERVICE_UNKNOWN: per the grpc_health_v1 proto, SERVICE_UNKNOWN is used
+// only by Watch to mean the service isn't registered at all, which is
+// handled separately by serviceStatus's ok return value.
+func toServingStatus(status health.Status) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	switch status {
+	case health.StatusUp:
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	case health.StatusDown:
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	default:
+		return grpc_health_v1.HealthCheckResponse_UNKNOWN
+	}
+}
@@ -0,0 +1,207 @@
+package healthgrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/panta/health"
+)
+
+// fakeChecker is a minimal health.Checker stub that always returns result
+// from Check, and hands out events (when set) from Subscribe.
+type fakeChecker struct {
+	result health.CheckerResult
+	events chan health.StatusEvent
+}
+
+func (f *fakeChecker) Check(ctx context.Context) health.CheckerResult { return f.result }
+func (f *fakeChecker) StartPeriodicChecks()                           {}
+func (f *fakeChecker) StopPeriodicChecks()                            {}
+func (f *fakeChecker) RegisterMetrics(reg prometheus.Registerer) error {
+	return nil
+}
+func (f *fakeChecker) Subscribe() (<-chan health.StatusEvent, func()) {
+	if f.events == nil {
+		f.events = make(chan health.StatusEvent)
+	}
+	return f.events, func() { close(f.events) }
+}
+
+func TestCheckReportsAggregatedStatusForEmptyService(t *testing.T) {
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusUp}}
+	server := NewGRPCHealthServer(checker)
+
+	resp, err := server.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+}
+
+func TestCheckReportsPerServiceStatus(t *testing.T) {
+	details := map[string]health.CheckResult{"db": {Status: health.StatusDown}}
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusDown, Details: &details}}
+	server := NewGRPCHealthServer(checker)
+
+	resp, err := server.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "db"})
+
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
+
+func TestCheckReturnsNotFoundForUnknownService(t *testing.T) {
+	details := map[string]health.CheckResult{"db": {Status: health.StatusUp}}
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusUp, Details: &details}}
+	server := NewGRPCHealthServer(checker)
+
+	_, err := server.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "cache"})
+
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, grpcstatus.Code(err))
+}
+
+// fakeWatchStream is a minimal grpc_health_v1.Health_WatchServer that
+// records every status sent to it on a channel a test can read from.
+type fakeWatchStream struct {
+	grpc_health_v1.Health_WatchServer
+	ctx  context.Context
+	sent chan grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+func (f *fakeWatchStream) Send(resp *grpc_health_v1.HealthCheckResponse) error {
+	f.sent <- resp.Status
+	return nil
+}
+func (f *fakeWatchStream) Context() context.Context     { return f.ctx }
+func (f *fakeWatchStream) SetHeader(metadata.MD) error  { return nil }
+func (f *fakeWatchStream) SendHeader(metadata.MD) error { return nil }
+func (f *fakeWatchStream) SetTrailer(metadata.MD)       {}
+func (f *fakeWatchStream) SendMsg(m interface{}) error  { return nil }
+func (f *fakeWatchStream) RecvMsg(m interface{}) error  { return nil }
+
+func TestWatchEmitsOnPerServiceTransitionEvenWhenAggregateIsUnchanged(t *testing.T) {
+	// database down + cache up -> aggregate down. database then recovers
+	// while cache goes down instead, so the aggregate stays down even
+	// though database's own status flips from NOT_SERVING to SERVING.
+	before := map[string]health.CheckResult{
+		"database": {Status: health.StatusDown},
+		"cache":    {Status: health.StatusUp},
+	}
+	checker := &fakeChecker{
+		result: health.CheckerResult{Status: health.StatusDown, Details: &before},
+		events: make(chan health.StatusEvent, 1),
+	}
+	server := NewGRPCHealthServer(checker)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeWatchStream{ctx: ctx, sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 2)}
+
+	done := make(chan error, 1)
+	go func() { done <- server.Watch(&grpc_health_v1.HealthCheckRequest{Service: "database"}, stream) }()
+
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, <-stream.sent)
+
+	after := map[string]health.CheckResult{
+		"database": {Status: health.StatusUp},
+		"cache":    {Status: health.StatusDown},
+	}
+	checker.events <- health.StatusEvent{Status: health.StatusDown, Checks: after}
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, <-stream.sent)
+
+	cancel()
+	<-done
+}
+
+func TestWatchSkipsEventsThatDoNotChangeTheRequestedService(t *testing.T) {
+	// cache flips from up to down while database stays up throughout, so a
+	// Watch for "database" must not see a second send.
+	before := map[string]health.CheckResult{
+		"database": {Status: health.StatusUp},
+		"cache":    {Status: health.StatusUp},
+	}
+	checker := &fakeChecker{
+		result: health.CheckerResult{Status: health.StatusUp, Details: &before},
+		events: make(chan health.StatusEvent, 1),
+	}
+	server := NewGRPCHealthServer(checker)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeWatchStream{ctx: ctx, sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 2)}
+
+	done := make(chan error, 1)
+	go func() { done <- server.Watch(&grpc_health_v1.HealthCheckRequest{Service: "database"}, stream) }()
+
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, <-stream.sent)
+
+	after := map[string]health.CheckResult{
+		"database": {Status: health.StatusUp},
+		"cache":    {Status: health.StatusDown},
+	}
+	checker.events <- health.StatusEvent{Status: health.StatusDown, Checks: after}
+	select {
+	case status := <-stream.sent:
+		t.Fatalf("expected no further send for an unrelated service change, got %v", status)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	<-done
+}
+
+func TestWatchDoesNotReExecuteChecksOnEveryEvent(t *testing.T) {
+	// Uses a real health.Checker (not a fake) so that, unlike a canned
+	// per-call result list, a Watch that re-triggers Check on every event
+	// is caught by the call counts going up on every event instead of only
+	// on the calls the test itself made.
+	var databaseCalls, cacheCalls int32
+	checker := health.NewChecker(
+		health.WithCheck(health.NewCheck("database", func(ctx context.Context) error {
+			n := atomic.AddInt32(&databaseCalls, 1)
+			if n == 1 {
+				return assert.AnError
+			}
+			return nil
+		})),
+		health.WithCheck(health.NewCheck("cache", func(ctx context.Context) error {
+			n := atomic.AddInt32(&cacheCalls, 1)
+			if n == 1 {
+				return nil
+			}
+			return assert.AnError
+		})),
+	)
+	server := NewGRPCHealthServer(checker)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeWatchStream{ctx: ctx, sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 2)}
+
+	done := make(chan error, 1)
+	go func() { done <- server.Watch(&grpc_health_v1.HealthCheckRequest{Service: "database"}, stream) }()
+
+	// The initial resolve is the only Check call Watch itself is allowed
+	// to make.
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, <-stream.sent)
+	require.EqualValues(t, 1, atomic.LoadInt32(&databaseCalls))
+	require.EqualValues(t, 1, atomic.LoadInt32(&cacheCalls))
+
+	// Drive a second, externally-triggered Check call, as an HTTP handler
+	// or the gRPC Check RPC would. database recovers while cache goes down
+	// instead, so the aggregate stays down but database's own status flips.
+	checker.Check(context.Background())
+
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, <-stream.sent)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&databaseCalls), "Watch must not have re-executed checks on its own")
+	assert.EqualValues(t, 2, atomic.LoadInt32(&cacheCalls), "Watch must not have re-executed checks on its own")
+
+	cancel()
+	<-done
+}
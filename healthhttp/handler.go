@@ -0,0 +1,174 @@
+// Package healthhttp renders a health.Checker's result as an HTTP response in
+// the format described by the IETF draft-inadarei-api-health-check spec
+// (https://www.ietf.org/archive/id/draft-ietf-health-check-response-01.html).
+package healthhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/panta/health"
+)
+
+// HandlerOption configures a Handler built by Handler.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	version      string
+	releaseID    string
+	unknownAs503 bool
+	hideOutput   bool
+	authorizer   func(*http.Request) (detailed bool, ok bool)
+}
+
+// WithVersion sets the static "version" field of the response body.
+func WithVersion(version string) HandlerOption {
+	return func(cfg *handlerConfig) { cfg.version = version }
+}
+
+// WithReleaseID sets the static "releaseId" field of the response body.
+func WithReleaseID(releaseID string) HandlerOption {
+	return func(cfg *handlerConfig) { cfg.releaseID = releaseID }
+}
+
+// WithUnknownAs503 makes the handler respond with 503 when the aggregated
+// status is health.StatusUnknown, instead of the default 200.
+func WithUnknownAs503(unknownAs503 bool) HandlerOption {
+	return func(cfg *handlerConfig) { cfg.unknownAs503 = unknownAs503 }
+}
+
+// WithHiddenOutput omits the "output" field (the raw error message of a
+// failing check) from the response. Use this for endpoints that are reachable
+// by unauthenticated callers. When WithAuthorizer is also set, its detailed
+// result decides whether output is included instead.
+func WithHiddenOutput() HandlerOption {
+	return func(cfg *handlerConfig) { cfg.hideOutput = true }
+}
+
+// WithAuthorizer lets a single endpoint serve both a terse public view and a
+// detailed view for authorized callers. authorizer is called once per
+// request; ok false rejects the request with 403, and detailed controls
+// whether output is included in the response.
+func WithAuthorizer(authorizer func(r *http.Request) (detailed bool, ok bool)) HandlerOption {
+	return func(cfg *handlerConfig) { cfg.authorizer = authorizer }
+}
+
+// genericComponentType is reported as the "componentType" of every check,
+// since health.Check does not track a more specific component type (such as
+// "datastore" or "system") to surface here.
+const genericComponentType = "component"
+
+// checkEntry is a single entry of the "checks" map in the response body.
+type checkEntry struct {
+	Status        string      `json:"status"`
+	ComponentType string      `json:"componentType,omitempty"`
+	ObservedValue interface{} `json:"observedValue,omitempty"`
+	ObservedUnit  string      `json:"observedUnit,omitempty"`
+	Time          string      `json:"time"`
+	Output        string      `json:"output,omitempty"`
+}
+
+// responseBody is the top-level JSON object rendered by Handler.
+type responseBody struct {
+	Status    string                  `json:"status"`
+	Version   string                  `json:"version,omitempty"`
+	ReleaseID string                  `json:"releaseId,omitempty"`
+	Checks    map[string][]checkEntry `json:"checks,omitempty"`
+}
+
+// Handler renders checker's result in the application/health+json format on
+// every request.
+func Handler(checker health.Checker, opts ...HandlerOption) http.Handler {
+	cfg := handlerConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hideOutput := cfg.hideOutput
+		if cfg.authorizer != nil {
+			detailed, ok := cfg.authorizer(r)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			hideOutput = !detailed
+		}
+
+		result := checker.Check(r.Context())
+
+		w.Header().Set("Content-Type", "application/health+json")
+		w.WriteHeader(statusCode(result.Status, cfg.unknownAs503))
+		_ = json.NewEncoder(w).Encode(toBody(result, cfg, hideOutput))
+	})
+}
+
+func toBody(result health.CheckerResult, cfg handlerConfig, hideOutput bool) responseBody {
+	body := responseBody{
+		Status:    ietfStatus(result.Status),
+		Version:   cfg.version,
+		ReleaseID: cfg.releaseID,
+	}
+	if result.Details == nil {
+		return body
+	}
+
+	body.Checks = make(map[string][]checkEntry, len(*result.Details))
+	for name, cr := range *result.Details {
+		status := ietfStatus(cr.Status)
+		observedAt := cr.Timestamp.Format(time.RFC3339)
+		var output string
+		if cr.Error != nil && !hideOutput {
+			output = *cr.Error
+		}
+
+		body.Checks[name+":status"] = []checkEntry{{
+			Status:        status,
+			ComponentType: genericComponentType,
+			Time:          observedAt,
+			Output:        output,
+		}}
+		if cr.Duration > 0 {
+			body.Checks[name+":responseTime"] = []checkEntry{{
+				Status:        status,
+				ComponentType: genericComponentType,
+				ObservedValue: cr.Duration.Seconds() * 1000,
+				ObservedUnit:  "ms",
+				Time:          observedAt,
+				Output:        output,
+			}}
+		}
+	}
+	return body
+}
+
+// ietfStatus maps this package's up/down/unknown status to the pass/warn/fail
+// vocabulary used by the IETF spec.
+func ietfStatus(status health.Status) string {
+	switch status {
+	case health.StatusUp:
+		return "pass"
+	case health.StatusDown:
+		return "fail"
+	default:
+		return "warn"
+	}
+}
+
+// statusCode maps the aggregated status to the HTTP status code to respond
+// with: 200 on pass/warn, 503 on fail, and 503 on warn-from-unknown when
+// unknownAs503 is set.
+func statusCode(aggregated health.Status, unknownAs503 bool) int {
+	switch aggregated {
+	case health.StatusDown:
+		return http.StatusServiceUnavailable
+	case health.StatusUnknown:
+		if unknownAs503 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	default:
+		return http.StatusOK
+	}
+}
@@ -0,0 +1,122 @@
+package healthhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panta/health"
+)
+
+// fakeChecker is a minimal health.Checker stub that always returns result.
+type fakeChecker struct {
+	result health.CheckerResult
+}
+
+func (f *fakeChecker) Check(ctx context.Context) health.CheckerResult { return f.result }
+func (f *fakeChecker) StartPeriodicChecks()                           {}
+func (f *fakeChecker) StopPeriodicChecks()                            {}
+func (f *fakeChecker) RegisterMetrics(reg prometheus.Registerer) error {
+	return nil
+}
+func (f *fakeChecker) Subscribe() (<-chan health.StatusEvent, func()) {
+	ch := make(chan health.StatusEvent)
+	return ch, func() { close(ch) }
+}
+
+func doRequest(t *testing.T, h http.Handler) (*http.Response, responseBody) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var body responseBody
+	require.NoError(t, json.NewDecoder(rec.Result().Body).Decode(&body))
+	return rec.Result(), body
+}
+
+func TestHandlerRendersPassWithStatus200(t *testing.T) {
+	details := map[string]health.CheckResult{"db": {Status: health.StatusUp}}
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusUp, Details: &details}}
+
+	resp, body := doRequest(t, Handler(checker, WithVersion("1.2.3")))
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/health+json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "pass", body.Status)
+	assert.Equal(t, "1.2.3", body.Version)
+	assert.Equal(t, "pass", body.Checks["db:status"][0].Status)
+	assert.Equal(t, "component", body.Checks["db:status"][0].ComponentType)
+}
+
+func TestHandlerSurfacesResponseTimeAsASeparateMeasurement(t *testing.T) {
+	details := map[string]health.CheckResult{
+		"db": {Status: health.StatusUp, Duration: 25 * time.Millisecond},
+	}
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusUp, Details: &details}}
+
+	_, body := doRequest(t, Handler(checker))
+
+	require.Len(t, body.Checks["db:responseTime"], 1)
+	entry := body.Checks["db:responseTime"][0]
+	assert.Equal(t, "pass", entry.Status)
+	assert.Equal(t, "component", entry.ComponentType)
+	assert.Equal(t, "ms", entry.ObservedUnit)
+	assert.Equal(t, 25.0, entry.ObservedValue)
+}
+
+func TestHandlerOmitsResponseTimeMeasurementWhenDurationIsZero(t *testing.T) {
+	details := map[string]health.CheckResult{"db": {Status: health.StatusUp}}
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusUp, Details: &details}}
+
+	_, body := doRequest(t, Handler(checker))
+
+	assert.NotContains(t, body.Checks, "db:responseTime")
+}
+
+func TestHandlerRendersFailWithStatus503(t *testing.T) {
+	errMsg := "boom"
+	details := map[string]health.CheckResult{"db": {Status: health.StatusDown, Error: &errMsg}}
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusDown, Details: &details}}
+
+	resp, body := doRequest(t, Handler(checker))
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "fail", body.Status)
+	assert.Equal(t, "boom", body.Checks["db:status"][0].Output)
+}
+
+func TestHandlerHidesOutputWhenConfigured(t *testing.T) {
+	errMsg := "boom"
+	details := map[string]health.CheckResult{"db": {Status: health.StatusDown, Error: &errMsg}}
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusDown, Details: &details}}
+
+	_, body := doRequest(t, Handler(checker, WithHiddenOutput()))
+
+	assert.Empty(t, body.Checks["db:status"][0].Output)
+}
+
+func TestHandlerUnknownAs503(t *testing.T) {
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusUnknown}}
+
+	resp, body := doRequest(t, Handler(checker, WithUnknownAs503(true)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "warn", body.Status)
+}
+
+func TestHandlerRejectsUnauthorizedRequests(t *testing.T) {
+	checker := &fakeChecker{result: health.CheckerResult{Status: health.StatusUp}}
+	h := Handler(checker, WithAuthorizer(func(r *http.Request) (bool, bool) { return false, false }))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+}
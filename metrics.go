@@ -0,0 +1,96 @@
+package health
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "health"
+
+// PrometheusCollector implements prometheus.Collector by exposing the status
+// of every check registered with a Checker. Collect reads directly from the
+// checker's cached state, so scraping it never triggers a check run.
+type PrometheusCollector struct {
+	checker *checkerImpl
+
+	up                  *prometheus.Desc
+	consecutiveFailures *prometheus.Desc
+	lastSuccess         *prometheus.Desc
+	duration            *prometheus.HistogramVec
+}
+
+// NewPrometheusCollector builds a PrometheusCollector for checker. checker
+// must have been created by NewChecker; any other implementation of Checker
+// causes NewPrometheusCollector to panic.
+func NewPrometheusCollector(checker Checker) *PrometheusCollector {
+	impl, ok := checker.(*checkerImpl)
+	if !ok {
+		panic("health: NewPrometheusCollector requires a Checker created by health.NewChecker")
+	}
+
+	c := &PrometheusCollector{
+		checker: impl,
+		up: prometheus.NewDesc(
+			metricsNamespace+"_check_up",
+			"Whether the check last reported down (0), up (1) or unknown (2).",
+			[]string{"check"}, nil,
+		),
+		consecutiveFailures: prometheus.NewDesc(
+			metricsNamespace+"_check_consecutive_failures",
+			"Number of consecutive failures observed for the check.",
+			[]string{"check"}, nil,
+		),
+		lastSuccess: prometheus.NewDesc(
+			metricsNamespace+"_check_last_success_timestamp_seconds",
+			"Unix timestamp of the last successful execution of the check.",
+			[]string{"check"}, nil,
+		),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "check_duration_seconds",
+			Help:      "Observed execution time of each check, in seconds.",
+		}, []string{"check"}),
+	}
+
+	impl.addObserver(func(name string, _ CheckResult, duration time.Duration) {
+		c.duration.WithLabelValues(name).Observe(duration.Seconds())
+	})
+
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.consecutiveFailures
+	ch <- c.lastSuccess
+	c.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	states := c.checker.snapshotStates()
+	for _, chk := range c.checker.cfg.checks {
+		state := states[chk.Name]
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, statusMetricValue(effectiveStatus(&state, chk)), chk.Name)
+		ch <- prometheus.MustNewConstMetric(c.consecutiveFailures, prometheus.GaugeValue, float64(state.consecutiveFails), chk.Name)
+		if !state.lastSuccessAt.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.lastSuccess, prometheus.GaugeValue, float64(state.lastSuccessAt.Unix()), chk.Name)
+		}
+	}
+	c.duration.Collect(ch)
+}
+
+// statusMetricValue maps status, as derived by effectiveStatus, to the 0/1/2
+// (down/up/unknown) scale used by the health_check_up gauge.
+func statusMetricValue(status Status) float64 {
+	switch status {
+	case StatusUp:
+		return 1
+	case StatusUnknown:
+		return 2
+	default:
+		return 0
+	}
+}
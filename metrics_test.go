@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectReportsUpForHealthyCheck(t *testing.T) {
+	checks := []*Check{
+		{Name: "database", Check: func(ctx context.Context) error { return nil }},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+	ckr.Check(context.Background())
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(NewPrometheusCollector(ckr)))
+
+	expected := strings.NewReader(`
+		# HELP health_check_up Whether the check last reported down (0), up (1) or unknown (2).
+		# TYPE health_check_up gauge
+		health_check_up{check="database"} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(reg, expected, "health_check_up"))
+}
+
+func TestCollectReportsUpWhileWithinMaxFailsGracePeriod(t *testing.T) {
+	// Arrange: the check has failed once, but it tolerates up to 5
+	// consecutive failures before being considered down, so Checker.Check
+	// still reports StatusUp.
+	checks := []*Check{
+		{
+			Name:     "database",
+			Check:    func(ctx context.Context) error { return fmt.Errorf("boom") },
+			maxFails: 5,
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+	result := ckr.Check(context.Background())
+	require.Equal(t, StatusUp, result.Status)
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(NewPrometheusCollector(ckr)))
+
+	// Assert: the exported gauge must agree with Checker.Check, not with the
+	// raw (failing) result.
+	expected := strings.NewReader(`
+		# HELP health_check_up Whether the check last reported down (0), up (1) or unknown (2).
+		# TYPE health_check_up gauge
+		health_check_up{check="database"} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(reg, expected, "health_check_up"))
+}
+
+func TestCollectReportsUnknownForSlowSuccess(t *testing.T) {
+	// Arrange: the check succeeds but its maxResponseTime is set below any
+	// achievable duration, so Checker.Check downgrades it to StatusUnknown.
+	checks := []*Check{
+		{
+			Name: "database",
+			Check: func(ctx context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			},
+			maxResponseTime: 1 * time.Millisecond,
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+	result := ckr.Check(context.Background())
+	require.Equal(t, StatusUnknown, result.Status)
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(NewPrometheusCollector(ckr)))
+
+	expected := strings.NewReader(`
+		# HELP health_check_up Whether the check last reported down (0), up (1) or unknown (2).
+		# TYPE health_check_up gauge
+		health_check_up{check="database"} 2
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(reg, expected, "health_check_up"))
+}
+
+func TestCollectReportsConsecutiveFailuresAsAGaugeThatCanDecrease(t *testing.T) {
+	// Arrange: fail twice, then succeed once, so consecutiveFails resets to
+	// 0. A prometheus.CounterValue may never decrease, so this metric must
+	// be exported as a gauge.
+	failing := true
+	checks := []*Check{
+		{
+			Name: "database",
+			Check: func(ctx context.Context) error {
+				if failing {
+					return fmt.Errorf("boom")
+				}
+				return nil
+			},
+		},
+	}
+	ckr := newChecker(healthCheckConfig{checks: checks})
+	ckr.Check(context.Background())
+	ckr.Check(context.Background())
+	failing = false
+	ckr.Check(context.Background())
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(NewPrometheusCollector(ckr)))
+
+	expected := strings.NewReader(`
+		# HELP health_check_consecutive_failures Number of consecutive failures observed for the check.
+		# TYPE health_check_consecutive_failures gauge
+		health_check_consecutive_failures{check="database"} 0
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(reg, expected, "health_check_consecutive_failures"))
+}
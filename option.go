@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures a Checker built via NewChecker.
+type Option func(*healthCheckConfig)
+
+// WithCheck registers check with the Checker being built.
+func WithCheck(check Check) Option {
+	return func(cfg *healthCheckConfig) {
+		cfg.checks = append(cfg.checks, &check)
+	}
+}
+
+// WithPeriodicCheckManualStart prevents the Checker from starting its
+// periodic checks automatically; call StartPeriodicChecks explicitly instead.
+func WithPeriodicCheckManualStart() Option {
+	return func(cfg *healthCheckConfig) {
+		cfg.manualPeriodicCheckStart = true
+	}
+}
+
+// WithStatusListener registers listener to be called whenever the aggregated
+// status returned by Checker.Check changes.
+func WithStatusListener(listener StatusChangeListener) Option {
+	return func(cfg *healthCheckConfig) {
+		cfg.statusChangeListeners = append(cfg.statusChangeListeners, listener)
+	}
+}
+
+// WithMaxErrorMessageLength truncates error messages surfaced in CheckResult
+// to at most length characters.
+func WithMaxErrorMessageLength(length uint) Option {
+	return func(cfg *healthCheckConfig) {
+		cfg.maxErrMsgLen = length
+	}
+}
+
+// WithWorkerPoolSize bounds how many checks belonging to the same
+// Check.DependsOn layer are executed concurrently. The default is 8.
+func WithWorkerPoolSize(n int) Option {
+	return func(cfg *healthCheckConfig) {
+		cfg.workerPoolSize = n
+	}
+}
+
+// CheckOption configures a Check built via NewCheck.
+type CheckOption func(*Check)
+
+// NewCheck builds a Check named name, running fn, configured by opts.
+func NewCheck(name string, fn func(ctx context.Context) error, opts ...CheckOption) Check {
+	check := Check{Name: name, Check: fn}
+	for _, opt := range opts {
+		opt(&check)
+	}
+	return check
+}
+
+// WithRefreshInterval makes the checker run this check periodically in the
+// background with the given interval, instead of inline on every call to
+// Checker.Check.
+func WithRefreshInterval(interval time.Duration) CheckOption {
+	return func(check *Check) {
+		check.refreshInterval = interval
+	}
+}
+
+// WithMaxTimeInError sets how long a check may keep failing before it is
+// considered StatusDown rather than StatusUp.
+func WithMaxTimeInError(d time.Duration) CheckOption {
+	return func(check *Check) {
+		check.maxTimeInError = d
+	}
+}
+
+// WithMaxFails sets how many consecutive failures a check may accumulate
+// before it is considered StatusDown rather than StatusUp.
+func WithMaxFails(n uint) CheckOption {
+	return func(check *Check) {
+		check.maxFails = n
+	}
+}
+
+// WithMaxResponseTime makes a check that succeeds but takes longer than d to
+// do so report StatusUnknown instead of StatusUp, so a degraded-but-passing
+// dependency is distinguishable from a fast, healthy one.
+func WithMaxResponseTime(d time.Duration) CheckOption {
+	return func(check *Check) {
+		check.maxResponseTime = d
+	}
+}
+
+// WithBackoff makes a periodic check back off exponentially while it keeps
+// failing: the Nth consecutive failure schedules the next run after
+// min(initial*factor^(N-1), max), plus jitter, instead of the check's fixed
+// refresh interval.
+func WithBackoff(initial, max time.Duration, factor float64) CheckOption {
+	return func(check *Check) {
+		check.backoff = &backoffConfig{initial: initial, max: max, factor: factor}
+	}
+}
@@ -0,0 +1,35 @@
+package health
+
+// Status expresses the health of a single Check or of the aggregated result
+// returned by a Checker.
+type Status string
+
+const (
+	// StatusUnknown indicates that a check has not produced a result yet, or
+	// that it succeeded but took longer than its maximum response time.
+	StatusUnknown Status = "unknown"
+	// StatusUp indicates that the checked component is healthy.
+	StatusUp Status = "up"
+	// StatusDown indicates that the checked component is not healthy.
+	StatusDown Status = "down"
+)
+
+// statusSeverity ranks statuses from least to most severe so that
+// aggregateStatus can pick the worst one across a set of results.
+var statusSeverity = map[Status]int{
+	StatusUp:      0,
+	StatusUnknown: 1,
+	StatusDown:    2,
+}
+
+// aggregateStatus derives a single Status from a set of check results by
+// picking the most severe status among them. An empty set is considered up.
+func aggregateStatus(results map[string]CheckResult) Status {
+	aggregated := StatusUp
+	for _, result := range results {
+		if statusSeverity[result.Status] > statusSeverity[aggregated] {
+			aggregated = result.Status
+		}
+	}
+	return aggregated
+}